@@ -0,0 +1,210 @@
+// Package auth issues and verifies the short-lived signed tokens that
+// authenticate the RPC events sent by editor clients.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TTL is how long an issued token remains valid.
+const TTL = 24 * time.Hour
+
+const keySize = 32
+
+var (
+	// ErrInvalidToken is returned when a token is malformed or its signature
+	// does not match any trusted key.
+	ErrInvalidToken = errors.New("auth: invalid token")
+	// ErrExpiredToken is returned when a token's exp claim is in the past.
+	ErrExpiredToken = errors.New("auth: token has expired")
+)
+
+// Claims identifies who a token was issued to and for how long it is valid.
+type Claims struct {
+	// Subject is the machine or client id the token was issued to.
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Issuer signs claims into tokens using a single HMAC key.
+type Issuer struct {
+	key []byte
+}
+
+// NewIssuer returns an Issuer that signs tokens with key.
+func NewIssuer(key []byte) *Issuer {
+	return &Issuer{key: key}
+}
+
+// Issue returns a signed token for subject that is valid for TTL.
+func (i *Issuer) Issue(subject string) (string, error) {
+	now := time.Now().UTC()
+	claims := Claims{
+		Subject:   subject,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(TTL).Unix(),
+	}
+	return sign(i.key, claims)
+}
+
+func sign(key []byte, claims Claims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("auth: could not marshal claims: %w", err)
+	}
+	encodedClaims := base64.RawURLEncoding.EncodeToString(payload)
+	return encodedClaims + "." + signature(key, encodedClaims), nil
+}
+
+func signature(key []byte, encodedClaims string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(encodedClaims))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verifier verifies that a token was signed by a trusted key and has not
+// expired.
+type Verifier interface {
+	Verify(token string) (Claims, error)
+}
+
+// KeyVerifier verifies tokens against the current signing key and, during
+// the grace window after a rotation, the previous key as well. This lets
+// already-issued tokens keep working until they expire naturally, as long
+// as the key is not rotated again first: only one previous key is kept, so
+// a second rotation stops accepting tokens signed with the oldest key.
+type KeyVerifier struct {
+	mutex       sync.RWMutex
+	currentKey  []byte
+	previousKey []byte
+}
+
+// NewKeyVerifier returns a KeyVerifier that trusts tokens signed with key.
+func NewKeyVerifier(key []byte) *KeyVerifier {
+	return &KeyVerifier{currentKey: key}
+}
+
+// RotateKey starts trusting newKey while still accepting tokens signed with
+// the key it replaces. Safe to call concurrently with Verify.
+func (v *KeyVerifier) RotateKey(newKey []byte) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.previousKey = v.currentKey
+	v.currentKey = newKey
+}
+
+// Verify checks the token's signature against the current key, falling
+// back to the previous key, and then checks that it has not expired. Safe
+// to call concurrently with RotateKey.
+func (v *KeyVerifier) Verify(token string) (Claims, error) {
+	v.mutex.RLock()
+	currentKey, previousKey := v.currentKey, v.previousKey
+	v.mutex.RUnlock()
+
+	claims, err := verifyWithKey(currentKey, token)
+	if err != nil && previousKey != nil {
+		claims, err = verifyWithKey(previousKey, token)
+	}
+	if err != nil {
+		return Claims{}, err
+	}
+
+	if time.Now().UTC().Unix() > claims.ExpiresAt {
+		return Claims{}, ErrExpiredToken
+	}
+
+	return claims, nil
+}
+
+func verifyWithKey(key []byte, token string) (Claims, error) {
+	encodedClaims, wantSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return Claims{}, ErrInvalidToken
+	}
+
+	if !hmac.Equal([]byte(signature(key, encodedClaims)), []byte(wantSignature)) {
+		return Claims{}, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedClaims)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// GenerateKey returns a new, random HMAC signing key.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("auth: could not generate key: %w", err)
+	}
+	return key, nil
+}
+
+// LoadOrGenerateKey reads the signing key from path, generating and
+// persisting a new one if it does not exist yet.
+func LoadOrGenerateKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, decodeErr := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if decodeErr != nil {
+			return nil, fmt.Errorf("auth: could not decode key at %s: %w", path, decodeErr)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("auth: could not read key at %s: %w", path, err)
+	}
+
+	key, err := GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("auth: could not create key directory: %w", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(key)
+	if err := os.WriteFile(path, []byte(encoded), 0o600); err != nil {
+		return nil, fmt.Errorf("auth: could not persist key at %s: %w", path, err)
+	}
+
+	return key, nil
+}
+
+// DefaultKeyPath returns the path the server persists its signing key to.
+func DefaultKeyPath() (string, error) {
+	return configPath("server.key")
+}
+
+// DefaultTokenPath returns the path the Vim client reads its token from.
+func DefaultTokenPath() (string, error) {
+	return configPath("token")
+}
+
+func configPath(name string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("auth: could not resolve home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "pulse", name), nil
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIssueAndVerify(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+
+	token, err := NewIssuer(key).Issue("client-1")
+	if err != nil {
+		t.Fatalf("Issue() returned error: %v", err)
+	}
+
+	verifier := NewKeyVerifier(key)
+	claims, err := verifier.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify() returned error: %v", err)
+	}
+	if claims.Subject != "client-1" {
+		t.Errorf("claims.Subject = %q, want %q", claims.Subject, "client-1")
+	}
+}
+
+func TestVerifyRejectsTamperedToken(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+
+	token, err := NewIssuer(key).Issue("client-1")
+	if err != nil {
+		t.Fatalf("Issue() returned error: %v", err)
+	}
+
+	tampered := token[:len(token)-1] + "x"
+	if _, err := NewKeyVerifier(key).Verify(tampered); err != ErrInvalidToken {
+		t.Errorf("Verify(tampered) = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestVerifyRejectsUnknownKey(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+	other, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+
+	token, err := NewIssuer(key).Issue("client-1")
+	if err != nil {
+		t.Fatalf("Issue() returned error: %v", err)
+	}
+
+	if _, err := NewKeyVerifier(other).Verify(token); err != ErrInvalidToken {
+		t.Errorf("Verify(token) = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestVerifyRejectsExpiredToken(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+
+	now := time.Now().UTC()
+	token, err := sign(key, Claims{
+		Subject:   "client-1",
+		IssuedAt:  now.Add(-2 * TTL).Unix(),
+		ExpiresAt: now.Add(-TTL).Unix(),
+	})
+	if err != nil {
+		t.Fatalf("sign() returned error: %v", err)
+	}
+
+	if _, err := NewKeyVerifier(key).Verify(token); err != ErrExpiredToken {
+		t.Errorf("Verify(token) = %v, want %v", err, ErrExpiredToken)
+	}
+}
+
+func TestRotateKeyAcceptsPreviousKeyDuringGraceWindow(t *testing.T) {
+	oldKey, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+	newKey, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+
+	token, err := NewIssuer(oldKey).Issue("client-1")
+	if err != nil {
+		t.Fatalf("Issue() returned error: %v", err)
+	}
+
+	verifier := NewKeyVerifier(oldKey)
+	verifier.RotateKey(newKey)
+
+	if _, err := verifier.Verify(token); err != nil {
+		t.Errorf("Verify(token signed with previous key) returned error: %v", err)
+	}
+
+	newToken, err := NewIssuer(newKey).Issue("client-1")
+	if err != nil {
+		t.Fatalf("Issue() returned error: %v", err)
+	}
+	if _, err := verifier.Verify(newToken); err != nil {
+		t.Errorf("Verify(token signed with current key) returned error: %v", err)
+	}
+}
+
+func TestRotateKeyStopsAcceptingTwoRotationsAgo(t *testing.T) {
+	firstKey, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+	secondKey, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+	thirdKey, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() returned error: %v", err)
+	}
+
+	token, err := NewIssuer(firstKey).Issue("client-1")
+	if err != nil {
+		t.Fatalf("Issue() returned error: %v", err)
+	}
+
+	verifier := NewKeyVerifier(firstKey)
+	verifier.RotateKey(secondKey)
+	verifier.RotateKey(thirdKey)
+
+	if _, err := verifier.Verify(token); err != ErrInvalidToken {
+		t.Errorf("Verify(token signed two rotations ago) = %v, want %v", err, ErrInvalidToken)
+	}
+}
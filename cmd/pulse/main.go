@@ -0,0 +1,21 @@
+// Command pulse is the CLI used by the Vim client, separate from the
+// long-running pulse-server daemon.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) == 3 && os.Args[1] == "token" && os.Args[2] == "issue" {
+		if err := issueToken(); err != nil {
+			fmt.Fprintln(os.Stderr, "pulse:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, "usage: pulse token issue")
+	os.Exit(1)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"code-harvest.conner.dev/auth"
+)
+
+// issueToken mints a token for this machine, signed with the same key the
+// server persists to disk, and writes it to the path the Vim client reads
+// its token from.
+func issueToken() error {
+	keyPath, err := auth.DefaultKeyPath()
+	if err != nil {
+		return err
+	}
+
+	key, err := auth.LoadOrGenerateKey(keyPath)
+	if err != nil {
+		return err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("pulse: could not resolve hostname: %w", err)
+	}
+
+	token, err := auth.NewIssuer(key).Issue(hostname)
+	if err != nil {
+		return err
+	}
+
+	tokenPath, err := auth.DefaultTokenPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(tokenPath), 0o700); err != nil {
+		return fmt.Errorf("pulse: could not create config directory: %w", err)
+	}
+
+	return os.WriteFile(tokenPath, []byte(token), 0o600)
+}
@@ -2,27 +2,86 @@ package main
 
 import (
 	"os"
+	"strconv"
+	"strings"
 
-	"code-harvest.conner.dev/internal/server"
-	"code-harvest.conner.dev/internal/storage"
+	"code-harvest.conner.dev/internal/app"
 	"code-harvest.conner.dev/pkg/logger"
+	"code-harvest.conner.dev/storage"
 )
 
-// Set by linker flags
+// Set by linker flags. logPath and logMaxBytes fall back to the env vars
+// below when empty.
 var (
-	serverName string
-	port       string
+	port        string
+	logPath     string
+	logMaxBytes string
 )
 
+const (
+	envLogPath         = "PULSE_LOG_PATH"
+	envLogMaxBytes     = "PULSE_LOG_MAX_BYTES"
+	defaultLogMaxBytes = 10 * 1024 * 1024
+	// envDashboardOrigins is a comma separated list of origins allowed to
+	// subscribe to /ws/live, e.g. "https://dashboard.example.com".
+	envDashboardOrigins = "PULSE_DASHBOARD_ORIGINS"
+)
+
+// dashboardOrigins returns the configured allowlist for the live websocket
+// endpoint, or nil if PULSE_DASHBOARD_ORIGINS is not set.
+func dashboardOrigins() []string {
+	raw := os.Getenv(envDashboardOrigins)
+	if raw == "" {
+		return nil
+	}
+	origins := strings.Split(raw, ",")
+	for i, origin := range origins {
+		origins[i] = strings.TrimSpace(origin)
+	}
+	return origins
+}
+
+// newLogger returns a rotating file logger when a log path has been
+// configured (via linker flags or PULSE_LOG_PATH), falling back to a
+// plain stdout logger otherwise.
+func newLogger() *logger.Logger {
+	path := logPath
+	if path == "" {
+		path = os.Getenv(envLogPath)
+	}
+	if path == "" {
+		return logger.New(os.Stdout, logger.LevelInfo)
+	}
+
+	maxBytes := int64(defaultLogMaxBytes)
+	raw := logMaxBytes
+	if raw == "" {
+		raw = os.Getenv(envLogMaxBytes)
+	}
+	if v, err := strconv.ParseInt(raw, 10, 64); err == nil && v > 0 {
+		maxBytes = v
+	}
+
+	log, err := logger.NewRotating(path, maxBytes, logger.LevelInfo)
+	if err != nil {
+		panic(err)
+	}
+	return log
+}
+
 func main() {
-	server, err := server.New(
-		serverName,
-		server.WithLog(logger.New(os.Stdout, logger.LevelInfo)),
-		server.WithStorage(storage.DiskStorage()),
+	log := newLogger()
+
+	a, err := app.New(
+		app.WithLog(log),
+		app.WithStorage(storage.New(log)),
+		app.WithDashboardOrigins(dashboardOrigins()...),
 	)
 	if err != nil {
 		panic(err)
 	}
 
-	server.Start(port)
+	if err := a.Start(port); err != nil {
+		panic(err)
+	}
 }
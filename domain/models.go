@@ -0,0 +1,101 @@
+package domain
+
+// Period identifies the granularity that a set of sessions has been
+// aggregated by.
+type Period string
+
+const (
+	Day   Period = "day"
+	Week  Period = "week"
+	Month Period = "month"
+	Year  Period = "year"
+)
+
+// Session is the representation of a coding session once it has been
+// persisted. It is a flattened view of everything that was tracked in
+// memory while the session was ongoing.
+type Session struct {
+	StartedAt  int64
+	EndedAt    int64
+	DurationMs int64
+	OS         string
+	Editor     string
+	Files      map[string]File
+}
+
+// File is a single file that was edited during a session, together with how
+// long it was open for.
+type File struct {
+	Name       string
+	Repository string
+	Filetype   string
+	Path       string
+	OpenedAt   int64
+	ClosedAt   int64
+	DurationMs int64
+}
+
+// Repository is the aggregated time spent in a single repository, broken
+// down per file.
+type Repository struct {
+	Name       string
+	DurationMs int64
+	Files      []File
+}
+
+// AggregatedSession is the result of grouping a set of raw sessions into a
+// single bucket, e.g. all the sessions that occurred on a given day.
+type AggregatedSession struct {
+	Period       Period
+	Date         int64
+	DateString   string
+	TotalTimeMs  int64
+	Repositories []Repository
+}
+
+// sessionRepositories merges the files from every session in the bucket into
+// a list of repositories, summing the duration per file across sessions.
+func sessionRepositories(sessions []Session) []Repository {
+	type fileKey struct {
+		repository string
+		path       string
+	}
+
+	durationByFile := make(map[fileKey]File)
+	order := make([]fileKey, 0)
+
+	for _, session := range sessions {
+		for _, file := range session.Files {
+			key := fileKey{repository: file.Repository, path: file.Path}
+			existing, ok := durationByFile[key]
+			if !ok {
+				durationByFile[key] = file
+				order = append(order, key)
+				continue
+			}
+			existing.DurationMs += file.DurationMs
+			durationByFile[key] = existing
+		}
+	}
+
+	repositories := make(map[string]*Repository)
+	repositoryOrder := make([]string, 0)
+
+	for _, key := range order {
+		file := durationByFile[key]
+		repo, ok := repositories[file.Repository]
+		if !ok {
+			repo = &Repository{Name: file.Repository}
+			repositories[file.Repository] = repo
+			repositoryOrder = append(repositoryOrder, file.Repository)
+		}
+		repo.DurationMs += file.DurationMs
+		repo.Files = append(repo.Files, file)
+	}
+
+	result := make([]Repository, 0, len(repositoryOrder))
+	for _, name := range repositoryOrder {
+		result = append(result, *repositories[name])
+	}
+	return result
+}
@@ -1,46 +1,187 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 
 	"code-harvest.conner.dev/truncate"
 )
 
-const yymmdd = "2006-01-02"
+const (
+	yyyymmdd = "2006-01-02"
+	yyyymm   = "2006-01"
+	yyyy     = "2006"
+)
 
 // Sessions is a slice of several Session structs
 type Sessions []Session
 
-// groupByDay groups the sessions by day
-func groupByDay(session []Session) map[int64][]Session {
+// bucketFunc returns the truncate function that buckets a StartedAt
+// timestamp into the start of its period.
+func bucketFunc(period Period) (func(int64) int64, error) {
+	switch period {
+	case Day:
+		return truncate.Day, nil
+	case Week:
+		return truncate.Week, nil
+	case Month:
+		return truncate.Month, nil
+	case Year:
+		return truncate.Year, nil
+	default:
+		return nil, fmt.Errorf("domain: unknown period %q", period)
+	}
+}
+
+// dateString formats a bucket's start date the way it should be displayed
+// for the given period, e.g. ISO week numbers for Week.
+func dateString(period Period, date int64) string {
+	t := time.UnixMilli(date).UTC()
+	switch period {
+	case Week:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case Month:
+		return t.Format(yyyymm)
+	case Year:
+		return t.Format(yyyy)
+	default:
+		return t.Format(yyyymmdd)
+	}
+}
+
+// groupByPeriod groups the sessions into buckets using bucket to determine
+// which bucket a session's StartedAt falls into.
+func groupByPeriod(sessions []Session, bucket func(int64) int64) map[int64][]Session {
 	buckets := make(map[int64][]Session)
-	for _, s := range session {
-		d := truncate.Day(s.StartedAt)
+	for _, s := range sessions {
+		d := bucket(s.StartedAt)
 		buckets[d] = append(buckets[d], s)
 	}
 	return buckets
 }
 
+// AggregateBy takes a slice of "raw" coding sessions and aggregates them
+// into buckets of the given period.
+func (sessions Sessions) AggregateBy(period Period) []AggregatedSession {
+	bucket, err := bucketFunc(period)
+	if err != nil {
+		panic(err)
+	}
+
+	buckets := groupByPeriod(sessions, bucket)
+	aggregatedSessions := make([]AggregatedSession, 0, len(buckets))
+
+	for date, bucketSessions := range buckets {
+		var totalTime int64 = 0
+		for _, s := range bucketSessions {
+			totalTime += s.DurationMs
+		}
+		aggregatedSessions = append(aggregatedSessions, AggregatedSession{
+			Period:       period,
+			Date:         date,
+			DateString:   dateString(period, date),
+			TotalTimeMs:  totalTime,
+			Repositories: sessionRepositories(bucketSessions),
+		})
+	}
+
+	return aggregatedSessions
+}
+
 // Aggregate takes a slice of "raw" coding sessions and aggregates them by day
 func (sessions Sessions) Aggregate() []AggregatedSession {
-	sessionsPerDay := groupByDay(sessions)
-	aggregatedSessions := make([]AggregatedSession, 0)
+	return sessions.AggregateBy(Day)
+}
+
+// RollUp composes the higher-level period "to" from already-aggregated
+// sessions, without re-scanning the raw sessions that produced them. This
+// lets, for example, a week of daily aggregates be turned into a single
+// weekly aggregate cheaply.
+func RollUp(from []AggregatedSession, to Period) []AggregatedSession {
+	bucket, err := bucketFunc(to)
+	if err != nil {
+		panic(err)
+	}
+
+	buckets := make(map[int64][]AggregatedSession)
+	order := make([]int64, 0)
+	for _, aggregated := range from {
+		date := bucket(aggregated.Date)
+		if _, ok := buckets[date]; !ok {
+			order = append(order, date)
+		}
+		buckets[date] = append(buckets[date], aggregated)
+	}
+
+	rolledUp := make([]AggregatedSession, 0, len(order))
+	for _, date := range order {
+		group := buckets[date]
 
-	for date, tempSessions := range sessionsPerDay {
-		dateString := time.Unix(0, date*int64(time.Millisecond)).Format(yymmdd)
 		var totalTime int64 = 0
-		for _, tempSession := range tempSessions {
-			totalTime += tempSession.DurationMs
+		repositoryGroups := make([][]Repository, 0, len(group))
+		for _, aggregated := range group {
+			totalTime += aggregated.TotalTimeMs
+			repositoryGroups = append(repositoryGroups, aggregated.Repositories)
 		}
-		session := AggregatedSession{
-			Period:       Day,
+
+		rolledUp = append(rolledUp, AggregatedSession{
+			Period:       to,
 			Date:         date,
-			DateString:   dateString,
+			DateString:   dateString(to, date),
 			TotalTimeMs:  totalTime,
-			Repositories: sessionRepositories(tempSessions),
+			Repositories: mergeRepositories(repositoryGroups),
+		})
+	}
+
+	return rolledUp
+}
+
+// mergeRepositories combines several lists of repositories into one,
+// summing the duration of any file that shows up more than once.
+func mergeRepositories(repositoryGroups [][]Repository) []Repository {
+	type fileKey struct {
+		repository string
+		path       string
+	}
+
+	durationByFile := make(map[fileKey]File)
+	fileOrder := make([]fileKey, 0)
+
+	for _, repositories := range repositoryGroups {
+		for _, repo := range repositories {
+			for _, file := range repo.Files {
+				key := fileKey{repository: repo.Name, path: file.Path}
+				existing, ok := durationByFile[key]
+				if !ok {
+					durationByFile[key] = file
+					fileOrder = append(fileOrder, key)
+					continue
+				}
+				existing.DurationMs += file.DurationMs
+				durationByFile[key] = existing
+			}
 		}
-		aggregatedSessions = append(aggregatedSessions, session)
 	}
 
-	return aggregatedSessions
-}
\ No newline at end of file
+	repositories := make(map[string]*Repository)
+	repositoryOrder := make([]string, 0)
+
+	for _, key := range fileOrder {
+		file := durationByFile[key]
+		repo, ok := repositories[key.repository]
+		if !ok {
+			repo = &Repository{Name: key.repository}
+			repositories[key.repository] = repo
+			repositoryOrder = append(repositoryOrder, key.repository)
+		}
+		repo.DurationMs += file.DurationMs
+		repo.Files = append(repo.Files, file)
+	}
+
+	merged := make([]Repository, 0, len(repositoryOrder))
+	for _, name := range repositoryOrder {
+		merged = append(merged, *repositories[name])
+	}
+	return merged
+}
@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func ms(year int, month time.Month, day, hour, min int) int64 {
+	return time.Date(year, month, day, hour, min, 0, 0, time.UTC).UnixMilli()
+}
+
+func newSession(startedAt int64, durationMs int64) Session {
+	return Session{
+		StartedAt:  startedAt,
+		EndedAt:    startedAt + durationMs,
+		DurationMs: durationMs,
+		OS:         "linux",
+		Editor:     "nvim",
+		Files: map[string]File{
+			"main.go": {
+				Name:       "main.go",
+				Repository: "pulse",
+				Filetype:   "go",
+				Path:       "main.go",
+				DurationMs: durationMs,
+			},
+		},
+	}
+}
+
+func TestAggregateByEmptyInput(t *testing.T) {
+	for _, period := range []Period{Day, Week, Month, Year} {
+		got := Sessions{}.AggregateBy(period)
+		if len(got) != 0 {
+			t.Errorf("AggregateBy(%s) on empty input = %v, want empty slice", period, got)
+		}
+	}
+}
+
+func TestAggregateByDSTBoundary(t *testing.T) {
+	// Both sessions fall within the same UTC calendar day, even though the
+	// day straddles the moment US clocks spring forward in local time.
+	sessions := Sessions{
+		newSession(ms(2024, time.March, 10, 6, 0), 1000),
+		newSession(ms(2024, time.March, 10, 8, 0), 2000),
+	}
+
+	got := sessions.AggregateBy(Day)
+	if len(got) != 1 {
+		t.Fatalf("AggregateBy(Day) = %d buckets, want 1", len(got))
+	}
+	if got[0].TotalTimeMs != 3000 {
+		t.Errorf("TotalTimeMs = %d, want 3000", got[0].TotalTimeMs)
+	}
+	if got[0].DateString != "2024-03-10" {
+		t.Errorf("DateString = %s, want 2024-03-10", got[0].DateString)
+	}
+}
+
+func TestAggregateByISOWeekYearRollover(t *testing.T) {
+	// Dec 31 2018 is a Monday, and starts ISO week 1 of 2019 because that
+	// week's Thursday (Jan 3rd) falls in 2019.
+	sessions := Sessions{
+		newSession(ms(2018, time.December, 31, 10, 0), 1000),
+		newSession(ms(2019, time.January, 2, 10, 0), 2000),
+	}
+
+	got := sessions.AggregateBy(Week)
+	if len(got) != 1 {
+		t.Fatalf("AggregateBy(Week) = %d buckets, want 1", len(got))
+	}
+	if got[0].DateString != "2019-W01" {
+		t.Errorf("DateString = %s, want 2019-W01", got[0].DateString)
+	}
+	if got[0].TotalTimeMs != 3000 {
+		t.Errorf("TotalTimeMs = %d, want 3000", got[0].TotalTimeMs)
+	}
+
+	// Dec 30 2018 is a Sunday, and belongs to ISO week 52 of 2018 rather
+	// than the week that rolls over into 2019.
+	previousWeek := Sessions{newSession(ms(2018, time.December, 30, 10, 0), 500)}
+	got = previousWeek.AggregateBy(Week)
+	if got[0].DateString != "2018-W52" {
+		t.Errorf("DateString = %s, want 2018-W52", got[0].DateString)
+	}
+}
+
+func TestRollUpEmptyInput(t *testing.T) {
+	got := RollUp(nil, Week)
+	if len(got) != 0 {
+		t.Errorf("RollUp(nil, Week) = %v, want empty slice", got)
+	}
+}
+
+func TestRollUpDailyIntoWeek(t *testing.T) {
+	daily := Sessions{
+		newSession(ms(2024, time.March, 11, 9, 0), 1000),
+		newSession(ms(2024, time.March, 12, 9, 0), 2000),
+		newSession(ms(2024, time.March, 18, 9, 0), 4000),
+	}.AggregateBy(Day)
+
+	weekly := RollUp(daily, Week)
+	if len(weekly) != 2 {
+		t.Fatalf("RollUp(daily, Week) = %d buckets, want 2", len(weekly))
+	}
+
+	totals := make(map[string]int64)
+	for _, w := range weekly {
+		totals[w.DateString] = w.TotalTimeMs
+	}
+	if totals["2024-W11"] != 3000 {
+		t.Errorf("2024-W11 total = %d, want 3000", totals["2024-W11"])
+	}
+	if totals["2024-W12"] != 4000 {
+		t.Errorf("2024-W12 total = %d, want 4000", totals["2024-W12"])
+	}
+
+	for _, w := range weekly {
+		if w.DateString == "2024-W11" {
+			if len(w.Repositories) != 1 {
+				t.Fatalf("expected 1 repository, got %d", len(w.Repositories))
+			}
+			repo := w.Repositories[0]
+			if repo.DurationMs != 3000 {
+				t.Errorf("repo duration = %d, want 3000", repo.DurationMs)
+			}
+			if len(repo.Files) != 1 || repo.Files[0].DurationMs != 3000 {
+				t.Errorf("expected main.go merged to 3000ms, got %+v", repo.Files)
+			}
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"net"
@@ -12,29 +13,57 @@ import (
 	"syscall"
 	"time"
 
+	"code-harvest.conner.dev/auth"
+	"code-harvest.conner.dev/domain"
 	"code-harvest.conner.dev/internal/models"
 	"code-harvest.conner.dev/internal/shared"
+	"code-harvest.conner.dev/live"
 	"code-harvest.conner.dev/pkg/clock"
 	"code-harvest.conner.dev/pkg/logger"
+	"code-harvest.conner.dev/truncate"
 )
 
 var HeartbeatTTL = time.Minute * 10
 var heartbeatInterval = time.Second * 10
 
+// aggregationInterval is how often today's sessions are re-aggregated and
+// upserted into storage. Re-running it against the same day is safe since
+// the aggregated document is upserted by period and date.
+var aggregationInterval = time.Hour
+
 type storage interface {
 	Connect() func()
 	Save(s interface{}) error
 }
 
 type app struct {
-	mutex          sync.Mutex
-	clock          clock.Clock
-	reader         MetadataReader
-	storage        storage
-	activeClientId string
-	lastHeartbeat  int64
-	session        *models.Session
-	log            *logger.Logger
+	mutex            sync.Mutex
+	clock            clock.Clock
+	reader           MetadataReader
+	storage          storage
+	activeClientId   string
+	lastHeartbeat    int64
+	session          *models.Session
+	log              *logger.Logger
+	verifier         Verifier
+	broadcaster      live.Broadcaster
+	dashboardOrigins []string
+}
+
+// sessionReader is implemented by storage backends that can also look up
+// the sessions saved on a given day. It is optional: when the configured
+// storage doesn't support it, live subscribers just don't get today's
+// aggregated totals in their initial snapshot.
+type sessionReader interface {
+	SessionsByDay(day int64) ([]domain.Session, error)
+}
+
+// aggregateSaver is implemented by storage backends that can persist the
+// buckets produced by domain.Sessions.Aggregate. It is optional, the same
+// way sessionReader is: when the configured storage doesn't support it,
+// the periodic aggregation job is simply skipped.
+type aggregateSaver interface {
+	SaveAggregated(aggregated []interface{}) error
 }
 
 type option func(*app) error
@@ -79,6 +108,53 @@ func WithLog(log *logger.Logger) option {
 	}
 }
 
+// Verifier is implemented by anything that can check an auth token presented
+// by a client. It is satisfied by *auth.KeyVerifier, and tests can plug in a
+// fake instead.
+type Verifier interface {
+	Verify(token string) (auth.Claims, error)
+}
+
+// rotatable is implemented by Verifiers that support accepting tokens signed
+// by a previous key during a grace window after rotation.
+type rotatable interface {
+	RotateKey(newKey []byte)
+}
+
+func WithAuth(verifier Verifier) option {
+	return func(a *app) error {
+		if verifier == nil {
+			return errors.New("verifier is nil")
+		}
+		a.verifier = verifier
+		return nil
+	}
+}
+
+// WithBroadcaster configures where FocusGained/OpenFile/SendHeartbeat cause
+// live events to be pushed. When it is not provided, New sets up a
+// websocket Hub served at /ws/live.
+func WithBroadcaster(broadcaster live.Broadcaster) option {
+	return func(a *app) error {
+		if broadcaster == nil {
+			return errors.New("broadcaster is nil")
+		}
+		a.broadcaster = broadcaster
+		return nil
+	}
+}
+
+// WithDashboardOrigins restricts the default websocket Hub to only accept
+// subscribers whose Origin header matches one of origins. It has no effect
+// if WithBroadcaster is also used. When it is not provided, the Hub falls
+// back to the websocket library's default same-origin check.
+func WithDashboardOrigins(origins ...string) option {
+	return func(a *app) error {
+		a.dashboardOrigins = origins
+		return nil
+	}
+}
+
 func New(opts ...option) (*app, error) {
 	a := &app{
 		clock:  clock.New(),
@@ -90,6 +166,25 @@ func New(opts ...option) (*app, error) {
 			return &app{}, err
 		}
 	}
+
+	if a.verifier == nil {
+		keyPath, err := auth.DefaultKeyPath()
+		if err != nil {
+			return &app{}, err
+		}
+		key, err := auth.LoadOrGenerateKey(keyPath)
+		if err != nil {
+			return &app{}, err
+		}
+		a.verifier = auth.NewKeyVerifier(key)
+	}
+
+	if a.broadcaster == nil {
+		// The verifier requires the same signed token as the RPC endpoint,
+		// so a dashboard tab can't subscribe to live events without one.
+		a.broadcaster = live.NewHub(live.DefaultPongWait, a.liveSnapshot, a.verifier, a.dashboardOrigins)
+	}
+
 	return a, nil
 }
 
@@ -108,7 +203,7 @@ func (app *app) FocusGained(event shared.Event, reply *string) error {
 	// open a new instance of VIM. If I'm, for example, jumping between a VIM split
 	// and a terminal with test output I don't want it to result in a new coding session.
 	if app.activeClientId == event.Id {
-		app.log.PrintDebug("Jumped back to the same instance of VIM.", nil)
+		app.log.PrintTrace(logger.CategorySession, "Jumped back to the same instance of VIM.", nil)
 		return nil
 	}
 
@@ -132,7 +227,7 @@ func (app *app) FocusGained(event shared.Event, reply *string) error {
 
 // OpenFile should be called by the *BufEnter* autocommand.
 func (app *app) OpenFile(event shared.Event, reply *string) error {
-	app.log.PrintDebug("Received OpenFile event", map[string]string{
+	app.log.PrintTrace(logger.CategorySession, "Received OpenFile event", map[string]string{
 		"path": event.Path,
 	})
 
@@ -168,7 +263,7 @@ func (app *app) SendHeartbeat(event shared.Event, reply *string) error {
 	// session. Therefore, we have to create a new one.
 	if app.session == nil {
 		message := "The session was ended by a previous heartbeat check. Creating a new one."
-		app.log.PrintDebug(message, map[string]string{
+		app.log.PrintTrace(logger.CategoryHeartbeat, message, map[string]string{
 			"clientId": event.Id,
 			"path":     event.Path,
 		})
@@ -203,7 +298,7 @@ func (app *app) EndSession(event shared.Event, reply *string) error {
 	// but won't have any session that we are tracking time for.
 	if app.activeClientId == "" && app.session == nil {
 		message := "The session was already ended, or possibly never started. Was there a previous hearbeat check?"
-		app.log.PrintDebug(message, nil)
+		app.log.PrintTrace(logger.CategorySession, message, nil)
 		return nil
 	}
 
@@ -215,7 +310,7 @@ func (app *app) EndSession(event shared.Event, reply *string) error {
 
 // Called by the ECG to determine whether the current session has gone stale or not.
 func (app *app) CheckHeartbeat() {
-	app.log.PrintDebug("Checking heartbeat", nil)
+	app.log.PrintTrace(logger.CategoryHeartbeat, "Checking heartbeat", nil)
 	if app.session != nil && app.lastHeartbeat+HeartbeatTTL.Milliseconds() < app.clock.GetTime() {
 		app.mutex.Lock()
 		defer app.mutex.Unlock()
@@ -235,7 +330,7 @@ func (app *app) updateCurrentFile(path string) {
 
 	fileMetadata, err := app.reader.Read(path)
 	if err != nil {
-		app.log.PrintDebug("Could not extract metadata for the path", map[string]string{
+		app.log.PrintTrace(logger.CategoryFileReader, "Could not extract metadata for the path", map[string]string{
 			"reason": err.Error(),
 		})
 		return
@@ -253,18 +348,33 @@ func (app *app) updateCurrentFile(path string) {
 	// Update the current file.
 	app.archiveCurrentFile(openedAt)
 	app.session.CurrentFile = &file
-	app.log.PrintDebug("Successfully updated the current file", map[string]string{
+	app.log.PrintTrace(logger.CategoryFileReader, "Successfully updated the current file", map[string]string{
 		"path": path,
 	})
+
+	app.broadcaster.Broadcast(live.Event{
+		Type:     live.FileChanged,
+		Path:     file.Path,
+		Repo:     file.Repository,
+		Filetype: file.Filetype,
+		OpenedAt: file.OpenedAt,
+	})
 }
 
 func (app *app) createSession(os, editor string) {
 	app.session = &models.Session{
+		ClientId:  app.activeClientId,
 		StartedAt: time.Now().UTC().UnixMilli(),
 		OS:        os,
 		Editor:    editor,
 		Files:     make(map[string]*models.File),
 	}
+
+	app.broadcaster.Broadcast(live.Event{
+		Type:   live.SessionStarted,
+		OS:     os,
+		Editor: editor,
+	})
 }
 
 func (app *app) saveSession() {
@@ -275,11 +385,11 @@ func (app *app) saveSession() {
 	}()
 
 	if app.session == nil {
-		app.log.PrintDebug("There was no session to save.", nil)
+		app.log.PrintTrace(logger.CategorySession, "There was no session to save.", nil)
 		return
 	}
 
-	app.log.PrintDebug("Saving the session.", nil)
+	app.log.PrintTrace(logger.CategorySession, "Saving the session.", nil)
 
 	// Set session duration and archive the current file.
 	endedAt := app.clock.GetTime()
@@ -305,10 +415,9 @@ func (app *app) saveSession() {
 	}
 
 	if len(app.session.Files) < 1 {
-		app.log.PrintDebug("The session had no files.", map[string]string{
+		app.log.PrintTrace(logger.CategorySession, "The session had no files.", map[string]string{
 			"clientId": app.activeClientId,
 		})
-		fmt.Println(app.session.Files)
 		return
 	}
 
@@ -316,25 +425,164 @@ func (app *app) saveSession() {
 	if err != nil {
 		app.log.PrintError(err, nil)
 	}
+
+	files := make([]string, 0, len(app.session.Files))
+	for path := range app.session.Files {
+		files = append(files, path)
+	}
+	app.broadcaster.Broadcast(live.Event{
+		Type:       live.SessionSaved,
+		DurationMs: app.session.DurationMs,
+		Files:      files,
+	})
+}
+
+// liveSnapshot builds the state a new live subscriber is sent right after
+// connecting: the in-progress session, if any, plus today's aggregated
+// totals when the configured storage supports looking those up.
+func (app *app) liveSnapshot() live.Snapshot {
+	app.mutex.Lock()
+	var sessionEvent *live.Event
+	if app.session != nil {
+		event := live.Event{
+			Type:   live.SessionStarted,
+			OS:     app.session.OS,
+			Editor: app.session.Editor,
+		}
+		if app.session.CurrentFile != nil {
+			event.Path = app.session.CurrentFile.Path
+			event.Repo = app.session.CurrentFile.Repository
+			event.Filetype = app.session.CurrentFile.Filetype
+			event.OpenedAt = app.session.CurrentFile.OpenedAt
+		}
+		sessionEvent = &event
+	}
+	app.mutex.Unlock()
+
+	snapshot := live.Snapshot{Type: "snapshot", Session: sessionEvent}
+
+	// The mutex is already released here: SessionsByDay is a network call
+	// for MongoStorage, and holding the lock across it would stall every
+	// FocusGained/OpenFile/SendHeartbeat for as long as the query takes.
+	if reader, ok := app.storage.(sessionReader); ok {
+		now := time.Now().UTC()
+		startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).UnixMilli()
+		sessions, err := reader.SessionsByDay(startOfDay)
+		if err != nil {
+			app.log.PrintDebug("Could not load today's sessions for the live snapshot", map[string]string{
+				"reason": err.Error(),
+			})
+			return snapshot
+		}
+		if aggregated := domain.Sessions(sessions).Aggregate(); len(aggregated) > 0 {
+			snapshot.Today = aggregated[0]
+		}
+	}
+
+	return snapshot
+}
+
+// runAggregationJob aggregates today's sessions and upserts the result into
+// storage, so that the aggregated collection stays current without anyone
+// having to re-scan the raw sessions. It is a no-op unless the configured
+// storage supports both looking sessions up by day and saving aggregates.
+func (app *app) runAggregationJob() {
+	reader, ok := app.storage.(sessionReader)
+	if !ok {
+		return
+	}
+	saver, ok := app.storage.(aggregateSaver)
+	if !ok {
+		return
+	}
+
+	startOfDay := truncate.Day(time.Now().UTC().UnixMilli())
+	sessions, err := reader.SessionsByDay(startOfDay)
+	if err != nil {
+		app.log.PrintError(err, nil)
+		return
+	}
+
+	aggregated := domain.Sessions(sessions).Aggregate()
+	if len(aggregated) == 0 {
+		return
+	}
+
+	items := make([]interface{}, len(aggregated))
+	for i, a := range aggregated {
+		items[i] = a
+	}
+
+	if err := saver.SaveAggregated(items); err != nil {
+		app.log.PrintError(err, nil)
+	}
+}
+
+// rotateAuthKey generates a new signing key and has the verifier start
+// trusting it, while it still accepts tokens signed with the key it
+// replaces until those tokens expire naturally or the key is rotated again.
+func (app *app) rotateAuthKey() {
+	rotater, ok := app.verifier.(rotatable)
+	if !ok {
+		app.log.PrintDebug("Verifier does not support key rotation.", nil)
+		return
+	}
+
+	keyPath, err := auth.DefaultKeyPath()
+	if err != nil {
+		app.log.PrintError(err, nil)
+		return
+	}
+
+	newKey, err := auth.GenerateKey()
+	if err != nil {
+		app.log.PrintError(err, nil)
+		return
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(newKey)
+	if err := os.WriteFile(keyPath, []byte(encoded), 0o600); err != nil {
+		app.log.PrintError(err, nil)
+		return
+	}
+
+	rotater.RotateKey(newKey)
+	app.log.PrintInfo("Rotated the auth signing key.", nil)
 }
 
 func startServer(app *app, port string) (net.Listener, error) {
 	// The proxy exposes the functions that we want to make available for remote
 	// procedure calls. Register the proxy as the RPC receiver.
-	proxy := shared.NewServerProxy(app)
+	proxy := shared.NewServerProxy(app, app.verifier, app.log)
 	err := rpc.RegisterName(shared.ServerName, proxy)
 	if err != nil {
 		return nil, err
 	}
 
 	rpc.HandleHTTP()
+
+	// Dashboards subscribe to live events alongside the RPC endpoint the
+	// Vim client talks to.
+	if handler, ok := app.broadcaster.(http.Handler); ok {
+		http.Handle("/ws/live", handler)
+	}
+
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
 	if err != nil {
 		return nil, err
 	}
 
-	err = http.Serve(listener, nil)
-	return listener, err
+	// Serve in the background so Start can reach its own event loop, which
+	// is what actually fires the heartbeat check, key rotation and the
+	// periodic aggregation job: a synchronous http.Serve here would block
+	// forever and none of those would ever run.
+	go func() {
+		if err := http.Serve(listener, nil); err != nil && !errors.Is(err, net.ErrClosed) {
+			app.log.PrintError(err, nil)
+		}
+	}()
+
+	return listener, nil
 }
 
 func (app *app) Start(port string) error {
@@ -353,13 +601,27 @@ func (app *app) Start(port string) error {
 	// Listen for shutdown channels and perform ECG checks.
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	// SIGHUP rotates the auth signing key. Tokens signed with the previous
+	// key keep verifying until they expire or the key is rotated again, so
+	// a single rotation does not invalidate clients that are already
+	// connected.
+	rotate := make(chan os.Signal, 1)
+	signal.Notify(rotate, syscall.SIGHUP)
+	app.log.PrintInfo("Listening for SIGHUP to rotate the auth signing key.", nil)
+
 	ecg := time.NewTicker(heartbeatInterval)
+	aggregation := time.NewTicker(aggregationInterval)
 
 	run := true
 	for run {
 		select {
+		case <-rotate:
+			app.rotateAuthKey()
 		case <-ecg.C:
 			app.CheckHeartbeat()
+		case <-aggregation.C:
+			app.runAggregationJob()
 		case <-quit:
 			run = false
 		}
@@ -367,5 +629,6 @@ func (app *app) Start(port string) error {
 
 	app.log.PrintInfo("Shutting down...", nil)
 	ecg.Stop()
+	aggregation.Stop()
 	return listener.Close()
 }
@@ -0,0 +1,37 @@
+// Package models contains the data structures that are tracked for a single
+// coding session while it is still in memory, before it gets persisted by a
+// storage backend.
+package models
+
+// Session represents a coding session for one active client (a single
+// instance of the editor). It is mutated in place while the session is
+// ongoing and handed off to storage once it has ended.
+type Session struct {
+	// ClientId identifies which instance of the editor the session belongs
+	// to. Together with StartedAt it uniquely identifies a session.
+	ClientId   string `bson:"clientId"`
+	StartedAt  int64  `bson:"startedAt"`
+	EndedAt    int64  `bson:"endedAt"`
+	DurationMs int64  `bson:"durationMs"`
+	OS         string `bson:"os"`
+	Editor     string `bson:"editor"`
+	// CurrentFile is the file that is currently being edited, if any.
+	CurrentFile *File `bson:"currentFile"`
+	// OpenFiles is every file that has been opened during the session, in
+	// the order they were opened. A file may appear more than once.
+	OpenFiles []*File `bson:"openFiles"`
+	// Files maps a file path to the merged duration for all edits of that
+	// file during the session.
+	Files map[string]*File `bson:"files"`
+}
+
+// File represents a single file that was opened during a coding session.
+type File struct {
+	Name       string `bson:"name"`
+	Repository string `bson:"repository"`
+	Filetype   string `bson:"filetype"`
+	Path       string `bson:"path"`
+	OpenedAt   int64  `bson:"openedAt"`
+	ClosedAt   int64  `bson:"closedAt"`
+	DurationMs int64  `bson:"durationMs"`
+}
@@ -0,0 +1,96 @@
+// Package shared contains the types that are shared between the pulse
+// server and its editor clients over RPC.
+package shared
+
+import "code-harvest.conner.dev/auth"
+
+// ServerName is the name the RPC service is registered under.
+const ServerName = "Pulse"
+
+// Event is sent by an editor client for every autocommand it wants the
+// server to know about.
+type Event struct {
+	Id     string
+	OS     string
+	Editor string
+	Path   string
+	// Token is a short-lived signed claim that proves the client is allowed
+	// to send events. It is verified by the ServerProxy before the event is
+	// dispatched to the receiver.
+	Token string
+}
+
+// receiver is the subset of the app that the RPC proxy dispatches events to.
+type receiver interface {
+	FocusGained(event Event, reply *string) error
+	OpenFile(event Event, reply *string) error
+	SendHeartbeat(event Event, reply *string) error
+	EndSession(event Event, reply *string) error
+}
+
+// Tracer is implemented by loggers that support categorized, env-gated
+// trace output. It is satisfied by *logger.Logger. The log passed to
+// NewServerProxy may be nil, in which case authentication is not traced.
+type Tracer interface {
+	PrintTrace(category, message string, properties map[string]string)
+}
+
+// ServerProxy is the net/rpc receiver that gets registered for the service.
+// It verifies the event's token before delegating to the receiver, so that
+// unauthenticated events never reach the application logic.
+type ServerProxy struct {
+	receiver receiver
+	verifier auth.Verifier
+	log      Tracer
+}
+
+// NewServerProxy returns a ServerProxy that authenticates every event
+// against verifier before forwarding it to receiver. log may be nil.
+func NewServerProxy(receiver receiver, verifier auth.Verifier, log Tracer) *ServerProxy {
+	return &ServerProxy{receiver: receiver, verifier: verifier, log: log}
+}
+
+func (p *ServerProxy) authenticate(event Event) error {
+	_, err := p.verifier.Verify(event.Token)
+	if p.log != nil {
+		if err != nil {
+			p.log.PrintTrace("rpc", "Rejected event with an invalid token", map[string]string{
+				"clientId": event.Id,
+				"reason":   err.Error(),
+			})
+		} else {
+			p.log.PrintTrace("rpc", "Authenticated event", map[string]string{
+				"clientId": event.Id,
+			})
+		}
+	}
+	return err
+}
+
+func (p *ServerProxy) FocusGained(event Event, reply *string) error {
+	if err := p.authenticate(event); err != nil {
+		return err
+	}
+	return p.receiver.FocusGained(event, reply)
+}
+
+func (p *ServerProxy) OpenFile(event Event, reply *string) error {
+	if err := p.authenticate(event); err != nil {
+		return err
+	}
+	return p.receiver.OpenFile(event, reply)
+}
+
+func (p *ServerProxy) SendHeartbeat(event Event, reply *string) error {
+	if err := p.authenticate(event); err != nil {
+		return err
+	}
+	return p.receiver.SendHeartbeat(event, reply)
+}
+
+func (p *ServerProxy) EndSession(event Event, reply *string) error {
+	if err := p.authenticate(event); err != nil {
+		return err
+	}
+	return p.receiver.EndSession(event, reply)
+}
@@ -0,0 +1,205 @@
+package live
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"code-harvest.conner.dev/auth"
+	"github.com/gorilla/websocket"
+)
+
+// DefaultPongWait is how long the hub waits for a pong before it considers a
+// connection dead and prunes it.
+const DefaultPongWait = 60 * time.Second
+
+// Verifier is implemented by anything that can check the auth token a
+// dashboard presents before it is allowed to subscribe. It is satisfied by
+// *auth.KeyVerifier, and tests can plug in another implementation instead.
+type Verifier interface {
+	Verify(token string) (auth.Claims, error)
+}
+
+// Hub is a websocket Broadcaster. Every subscriber gets its own buffered
+// send channel, so a slow subscriber can never back-pressure the callers
+// broadcasting events.
+type Hub struct {
+	mutex       sync.Mutex
+	subscribers map[*subscriber]struct{}
+	broadcast   chan Event
+	pongWait    time.Duration
+	snapshot    SnapshotFunc
+	verifier    Verifier
+	upgrader    websocket.Upgrader
+}
+
+// NewHub returns a Hub that prunes subscribers that fail to respond to a
+// ping within pongWait. snapshot is called once per new subscriber to build
+// the initial state it is sent. A subscriber must present a token that
+// verifier accepts, and an Origin header matching one of allowedOrigins, or
+// the upgrade is refused; when allowedOrigins is empty the websocket
+// library's default same-origin check is used instead.
+func NewHub(pongWait time.Duration, snapshot SnapshotFunc, verifier Verifier, allowedOrigins []string) *Hub {
+	if pongWait <= 0 {
+		pongWait = DefaultPongWait
+	}
+	h := &Hub{
+		subscribers: make(map[*subscriber]struct{}),
+		broadcast:   make(chan Event, 256),
+		pongWait:    pongWait,
+		snapshot:    snapshot,
+		verifier:    verifier,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     checkOrigin(allowedOrigins),
+		},
+	}
+	go h.run()
+	return h
+}
+
+// checkOrigin returns a CheckOrigin func that only allows the given origins
+// through. A nil return leaves gorilla's own CheckOrigin unset, which falls
+// back to its default same-origin check.
+func checkOrigin(allowedOrigins []string) func(r *http.Request) bool {
+	if len(allowedOrigins) == 0 {
+		return nil
+	}
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		for _, allowed := range allowedOrigins {
+			if origin == allowed {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Broadcast fans event out to every current subscriber. It never blocks on
+// a slow subscriber: if its send buffer is full the event is dropped for
+// that subscriber only.
+func (h *Hub) Broadcast(event Event) {
+	h.broadcast <- event
+}
+
+func (h *Hub) run() {
+	for event := range h.broadcast {
+		h.mutex.Lock()
+		for sub := range h.subscribers {
+			select {
+			case sub.send <- event:
+			default:
+				log.Printf("live: dropping event for slow subscriber")
+			}
+		}
+		h.mutex.Unlock()
+	}
+}
+
+// ServeHTTP authenticates the subscriber, upgrades the connection to a
+// websocket, sends the initial snapshot, and then streams every broadcast
+// event until the connection is closed or goes stale.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.verifier != nil {
+		if _, err := h.verifier.Verify(r.URL.Query().Get("token")); err != nil {
+			http.Error(w, "invalid or missing token", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	sub := &subscriber{conn: conn, send: make(chan Event, 16)}
+	h.add(sub)
+	defer h.remove(sub)
+
+	if h.snapshot != nil {
+		sub.writeJSON(h.snapshot())
+	}
+
+	go sub.readPump(h.pongWait)
+	sub.writePump(h.pongWait)
+}
+
+func (h *Hub) add(sub *subscriber) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.subscribers[sub] = struct{}{}
+}
+
+func (h *Hub) remove(sub *subscriber) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	delete(h.subscribers, sub)
+	sub.conn.Close()
+}
+
+// subscriber wraps a single websocket connection. Every write goes through
+// mutex, since the gorilla/websocket connection does not support concurrent
+// writers.
+type subscriber struct {
+	mutex sync.Mutex
+	conn  *websocket.Conn
+	send  chan Event
+}
+
+func (s *subscriber) writeJSON(v interface{}) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.conn.WriteJSON(v)
+}
+
+// readPump only exists to process control frames (pongs). Dashboards don't
+// send us anything meaningful, so any message received is discarded. When
+// the peer stops responding to pings, ReadMessage fails once the read
+// deadline lapses; closing the connection here makes writePump's next
+// write fail immediately instead of leaking the goroutine and socket until
+// some later write happens to notice.
+func (s *subscriber) readPump(pongWait time.Duration) {
+	defer s.conn.Close()
+
+	s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	s.conn.SetPongHandler(func(string) error {
+		s.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+	for {
+		if _, _, err := s.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// writePump relays broadcast events to the connection and pings it on an
+// interval so that dead browser tabs get pruned rather than leaking
+// goroutines and subscriptions forever.
+func (s *subscriber) writePump(pongWait time.Duration) {
+	pingInterval := pongWait * 9 / 10
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-s.send:
+			if !ok {
+				return
+			}
+			if err := s.writeJSON(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			s.mutex.Lock()
+			err := s.conn.WriteMessage(websocket.PingMessage, nil)
+			s.mutex.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
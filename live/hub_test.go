@@ -0,0 +1,173 @@
+package live
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"code-harvest.conner.dev/auth"
+	"github.com/gorilla/websocket"
+)
+
+func newTestHub() *Hub {
+	return &Hub{
+		subscribers: make(map[*subscriber]struct{}),
+		broadcast:   make(chan Event, 256),
+	}
+}
+
+func TestBroadcastDeliversToSubscribers(t *testing.T) {
+	h := newTestHub()
+	go h.run()
+
+	sub := &subscriber{send: make(chan Event, 1)}
+	h.add(sub)
+
+	h.Broadcast(Event{Type: FileChanged, Path: "main.go"})
+
+	select {
+	case event := <-sub.send:
+		if event.Path != "main.go" {
+			t.Errorf("event.Path = %q, want %q", event.Path, "main.go")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscriber never received the broadcast event")
+	}
+}
+
+func TestBroadcastDropsForSlowSubscriber(t *testing.T) {
+	h := newTestHub()
+	go h.run()
+
+	sub := &subscriber{send: make(chan Event, 1)}
+	h.add(sub)
+
+	// Fill the subscriber's buffer, then send a second event that has
+	// nowhere to go. Broadcast must not block waiting for it to drain.
+	h.Broadcast(Event{Type: SessionStarted})
+	done := make(chan struct{})
+	go func() {
+		h.Broadcast(Event{Type: SessionSaved})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Broadcast blocked on a slow subscriber instead of dropping the event")
+	}
+}
+
+func TestPruneStopsDelivery(t *testing.T) {
+	h := newTestHub()
+	go h.run()
+
+	sub := &subscriber{send: make(chan Event, 1)}
+	h.add(sub)
+
+	h.mutex.Lock()
+	delete(h.subscribers, sub)
+	h.mutex.Unlock()
+
+	h.Broadcast(Event{Type: FileChanged, Path: "main.go"})
+
+	select {
+	case <-sub.send:
+		t.Fatal("pruned subscriber still received a broadcast event")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestDeadConnectionIsPruned drives a real websocket connection end to end:
+// the client dials in and then never reads anything, exactly like a
+// dashboard tab whose laptop went to sleep. It should get pruned once it
+// stops responding to pings, instead of leaking a goroutine and socket.
+func TestDeadConnectionIsPruned(t *testing.T) {
+	key, err := auth.GenerateKey()
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+	issuer := auth.NewIssuer(key)
+	token, err := issuer.Issue("dashboard")
+	if err != nil {
+		t.Fatalf("could not issue token: %v", err)
+	}
+
+	h := NewHub(50*time.Millisecond, nil, auth.NewKeyVerifier(key), nil)
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "?token=" + token
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("could not dial %s: %v", wsURL, err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		h.mutex.Lock()
+		remaining := len(h.subscribers)
+		h.mutex.Unlock()
+		if remaining == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("dead subscriber was never pruned")
+}
+
+// TestServeHTTPRejectsInvalidToken ensures a subscriber that does not
+// present a token the verifier accepts never gets upgraded, closing the
+// read channel a dashboard's origin would otherwise have into live events.
+func TestServeHTTPRejectsInvalidToken(t *testing.T) {
+	key, err := auth.GenerateKey()
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+
+	h := NewHub(50*time.Millisecond, nil, auth.NewKeyVerifier(key), nil)
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err == nil {
+		t.Fatal("expected the upgrade to be rejected without a valid token")
+	}
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("response = %v, want status %d", resp, http.StatusUnauthorized)
+	}
+}
+
+// TestServeHTTPRejectsDisallowedOrigin ensures that, once allowedOrigins is
+// configured, a request from any other origin is refused before the
+// connection is upgraded.
+func TestServeHTTPRejectsDisallowedOrigin(t *testing.T) {
+	key, err := auth.GenerateKey()
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+	issuer := auth.NewIssuer(key)
+	token, err := issuer.Issue("dashboard")
+	if err != nil {
+		t.Fatalf("could not issue token: %v", err)
+	}
+
+	h := NewHub(50*time.Millisecond, nil, auth.NewKeyVerifier(key), []string{"https://dashboard.example.com"})
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	header := http.Header{}
+	header.Set("Origin", "https://evil.example.com")
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "?token=" + token
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL, header)
+	if err == nil {
+		t.Fatal("expected the upgrade to be rejected for a disallowed origin")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("response = %v, want status %d", resp, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,60 @@
+// Package live implements the live-stream of coding events that dashboards
+// can subscribe to over a websocket.
+package live
+
+// EventType identifies the shape of an Event.
+type EventType string
+
+const (
+	// FileChanged is pushed whenever the file being edited changes.
+	FileChanged EventType = "file_changed"
+	// SessionStarted is pushed whenever a new coding session begins.
+	SessionStarted EventType = "session_started"
+	// SessionSaved is pushed whenever a coding session ends and is handed
+	// off to storage.
+	SessionSaved EventType = "session_saved"
+)
+
+// Event is the JSON payload pushed to every subscriber. Only the fields that
+// are relevant to Type are populated.
+type Event struct {
+	Type EventType `json:"type"`
+
+	// FileChanged
+	Path     string `json:"path,omitempty"`
+	Repo     string `json:"repo,omitempty"`
+	Filetype string `json:"filetype,omitempty"`
+	OpenedAt int64  `json:"opened_at,omitempty"`
+
+	// SessionStarted
+	OS     string `json:"os,omitempty"`
+	Editor string `json:"editor,omitempty"`
+
+	// SessionSaved
+	DurationMs int64    `json:"duration_ms,omitempty"`
+	Files      []string `json:"files,omitempty"`
+}
+
+// Snapshot is what a new subscriber is sent right after connecting, so that
+// it does not have to wait for the next incremental event to know the
+// current state.
+type Snapshot struct {
+	Type EventType `json:"type"`
+
+	// Session is nil when there is no session currently in progress.
+	Session *Event `json:"session,omitempty"`
+	// Today is the result of aggregating every session saved so far today.
+	Today interface{} `json:"today,omitempty"`
+}
+
+// SnapshotFunc builds the snapshot that gets sent to a subscriber right
+// after it connects.
+type SnapshotFunc func() Snapshot
+
+// Broadcaster is implemented by anything that can fan out live events to
+// subscribers. The default implementation is a websocket Hub, but tests can
+// substitute a fake, and an alternative sink (e.g. an SSE bridge) could
+// implement it too.
+type Broadcaster interface {
+	Broadcast(event Event)
+}
@@ -0,0 +1,153 @@
+// Package logger implements a small leveled, JSON-line logger with
+// category-filtered trace output modeled on the STTRACE convention.
+package logger
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level represents the severity of a log entry.
+type Level int8
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelError
+	LevelFatal
+	LevelOff
+)
+
+// String returns the human readable name for the level.
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelError:
+		return "ERROR"
+	case LevelFatal:
+		return "FATAL"
+	default:
+		return ""
+	}
+}
+
+// Trace categories. All is a wildcard that enables every one of them.
+const (
+	CategoryRPC        = "rpc"
+	CategorySession    = "session"
+	CategoryStorage    = "storage"
+	CategoryHeartbeat  = "heartbeat"
+	CategoryFileReader = "filereader"
+	CategoryAll        = "all"
+)
+
+// envTrace is parsed once at startup into the set of enabled categories.
+const envTrace = "PULSE_TRACE"
+
+// ParseTraceCategories splits a comma separated list of categories, as read
+// from PULSE_TRACE, into a set suitable for Logger.
+func ParseTraceCategories(value string) map[string]bool {
+	categories := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			categories[part] = true
+		}
+	}
+	return categories
+}
+
+// Logger writes leveled, JSON-encoded log entries to an underlying writer.
+// It is safe for concurrent use.
+type Logger struct {
+	mutex           sync.Mutex
+	out             io.Writer
+	minLevel        Level
+	traceCategories map[string]bool
+}
+
+// New returns a Logger that writes entries at or above minLevel to out.
+// Trace categories are read from the PULSE_TRACE env var.
+func New(out io.Writer, minLevel Level) *Logger {
+	return &Logger{
+		out:             out,
+		minLevel:        minLevel,
+		traceCategories: ParseTraceCategories(os.Getenv(envTrace)),
+	}
+}
+
+type entry struct {
+	Level      string            `json:"level"`
+	Time       string            `json:"time"`
+	Message    string            `json:"message"`
+	Category   string            `json:"category,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+	Trace      string            `json:"trace,omitempty"`
+}
+
+func (l *Logger) print(level Level, category, message string, properties map[string]string) {
+	if level < l.minLevel {
+		return
+	}
+
+	e := entry{
+		Level:      level.String(),
+		Time:       time.Now().UTC().Format(time.RFC3339),
+		Message:    message,
+		Category:   category,
+		Properties: properties,
+	}
+	if level >= LevelError {
+		e.Trace = string(debug.Stack())
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		data = []byte(level.String() + ": " + message)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.out.Write(append(data, '\n'))
+}
+
+// PrintDebug logs a debug level message.
+func (l *Logger) PrintDebug(message string, properties map[string]string) {
+	l.print(LevelDebug, "", message, properties)
+}
+
+// PrintInfo logs an info level message.
+func (l *Logger) PrintInfo(message string, properties map[string]string) {
+	l.print(LevelInfo, "", message, properties)
+}
+
+// PrintError logs an error, including a stack trace.
+func (l *Logger) PrintError(err error, properties map[string]string) {
+	l.print(LevelError, "", err.Error(), properties)
+}
+
+// PrintFatal logs an error as fatal, including a stack trace, and then exits
+// the process with a non-zero status code.
+func (l *Logger) PrintFatal(err error, properties map[string]string) {
+	l.print(LevelFatal, "", err.Error(), properties)
+	os.Exit(1)
+}
+
+// PrintTrace logs a debug level message tagged with category. It is a
+// no-op unless that category (or CategoryAll) was enabled via PULSE_TRACE,
+// so call sites can leave trace statements in place permanently without
+// paying for them in normal operation.
+func (l *Logger) PrintTrace(category, message string, properties map[string]string) {
+	if !l.traceCategories[CategoryAll] && !l.traceCategories[category] {
+		return
+	}
+	l.print(LevelDebug, category, message, properties)
+}
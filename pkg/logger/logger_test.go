@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func newTestLogger(out *bytes.Buffer, traceEnv string) *Logger {
+	os.Setenv(envTrace, traceEnv)
+	defer os.Unsetenv(envTrace)
+	return New(out, LevelDebug)
+}
+
+func TestPrintTraceGatedByCategory(t *testing.T) {
+	tests := []struct {
+		name       string
+		traceEnv   string
+		category   string
+		wantOutput bool
+	}{
+		{"category enabled", "storage", "storage", true},
+		{"different category disabled", "rpc", "storage", false},
+		{"no categories enabled", "", "storage", false},
+		{"all enables every category", "all", "heartbeat", true},
+		{"multiple categories", "rpc,storage", "storage", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			log := newTestLogger(&out, tt.traceEnv)
+
+			log.PrintTrace(tt.category, "a trace message", nil)
+
+			if got := out.Len() > 0; got != tt.wantOutput {
+				t.Errorf("wrote output = %v, want %v (output: %q)", got, tt.wantOutput, out.String())
+			}
+		})
+	}
+}
+
+func TestPrintTraceIncludesCategoryInEntry(t *testing.T) {
+	var out bytes.Buffer
+	log := newTestLogger(&out, "storage")
+
+	log.PrintTrace("storage", "flushed", map[string]string{"count": "3"})
+
+	var e entry
+	if err := json.Unmarshal(out.Bytes(), &e); err != nil {
+		t.Fatalf("could not unmarshal log entry: %v", err)
+	}
+	if e.Category != "storage" {
+		t.Errorf("entry.Category = %q, want %q", e.Category, "storage")
+	}
+	if e.Message != "flushed" {
+		t.Errorf("entry.Message = %q, want %q", e.Message, "flushed")
+	}
+	if e.Properties["count"] != "3" {
+		t.Errorf("entry.Properties[\"count\"] = %q, want %q", e.Properties["count"], "3")
+	}
+}
+
+func TestParseTraceCategories(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"empty", "", nil},
+		{"single", "storage", []string{"storage"}},
+		{"multiple with spaces", "storage, rpc , heartbeat", []string{"storage", "rpc", "heartbeat"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseTraceCategories(tt.value)
+			for _, category := range tt.want {
+				if !got[category] {
+					t.Errorf("ParseTraceCategories(%q)[%q] = false, want true", tt.value, category)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("ParseTraceCategories(%q) = %v, want %d categories", tt.value, got, len(tt.want))
+			}
+		})
+	}
+}
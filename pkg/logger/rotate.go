@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// maxRotationSlots bounds how many rotated files we'll search for before
+// giving up, matching the NNN 3-digit suffix.
+const maxRotationSlots = 999
+
+// rotatingWriter is an io.Writer over a file that rotates itself once it
+// exceeds maxBytes: the current file is renamed to path.NNN, using the next
+// free 3-digit slot, and a fresh file is opened in its place.
+type rotatingWriter struct {
+	mutex    sync.Mutex
+	path     string
+	maxBytes int64
+	file     *os.File
+	size     int64
+}
+
+func newRotatingWriter(path string, maxBytes int64) (*rotatingWriter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("logger: could not open %s: %w", path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("logger: could not stat %s: %w", path, err)
+	}
+
+	return &rotatingWriter{
+		path:     path,
+		maxBytes: maxBytes,
+		file:     file,
+		size:     info.Size(),
+	}, nil
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.size+int64(len(p)) > w.maxBytes {
+		// Rotating is best-effort: if it fails we keep writing to the
+		// current file rather than losing log output.
+		w.doRotate()
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// doRotate closes the current file, renames it to the next free path.NNN
+// slot, and reopens path as a fresh, empty file. If every slot up to
+// maxRotationSlots is taken it gives up cleanly and keeps appending to the
+// current file instead.
+func (w *rotatingWriter) doRotate() {
+	slot := -1
+	for i := 1; i <= maxRotationSlots; i++ {
+		candidate := fmt.Sprintf("%s.%03d", w.path, i)
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			slot = i
+			break
+		}
+	}
+	if slot == -1 {
+		return
+	}
+
+	if err := w.file.Close(); err != nil {
+		return
+	}
+
+	if err := os.Rename(w.path, fmt.Sprintf("%s.%03d", w.path, slot)); err != nil {
+		// The rename failed, so the old file is still at w.path. Reopen it
+		// in append mode and try again next time.
+		file, openErr := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if openErr == nil {
+			w.file = file
+		}
+		return
+	}
+
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return
+	}
+	w.file = file
+	w.size = 0
+}
+
+// NewRotating returns a Logger that writes to path, rotating it to
+// path.NNN once it exceeds maxBytes.
+func NewRotating(path string, maxBytes int64, minLevel Level) (*Logger, error) {
+	writer, err := newRotatingWriter(path, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Logger{
+		out:             writer,
+		minLevel:        minLevel,
+		traceCategories: ParseTraceCategories(os.Getenv(envTrace)),
+	}, nil
+}
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingWriterRotatesOnceMaxBytesExceeded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pulse.log")
+	w, err := newRotatingWriter(path, 10)
+	if err != nil {
+		t.Fatalf("newRotatingWriter() returned error: %v", err)
+	}
+
+	if _, err := w.Write([]byte("0123456789")); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	if _, err := w.Write([]byte("overflow")); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+
+	if _, err := os.Stat(path + ".001"); err != nil {
+		t.Errorf("expected %s to exist after rotation: %v", path+".001", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read %s: %v", path, err)
+	}
+	if string(data) != "overflow" {
+		t.Errorf("current log file = %q, want %q", data, "overflow")
+	}
+}
+
+func TestRotatingWriterSkipsTakenSlots(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pulse.log")
+	if err := os.WriteFile(path+".001", []byte("taken"), 0o644); err != nil {
+		t.Fatalf("could not seed %s: %v", path+".001", err)
+	}
+
+	w, err := newRotatingWriter(path, 1)
+	if err != nil {
+		t.Fatalf("newRotatingWriter() returned error: %v", err)
+	}
+	w.doRotate()
+
+	if _, err := os.Stat(path + ".002"); err != nil {
+		t.Errorf("expected %s to exist, slot .001 was already taken: %v", path+".002", err)
+	}
+}
+
+func TestRotatingWriterGivesUpWhenSlotsExhausted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pulse.log")
+	for i := 1; i <= maxRotationSlots; i++ {
+		if err := os.WriteFile(fmt.Sprintf("%s.%03d", path, i), nil, 0o644); err != nil {
+			t.Fatalf("could not seed slot %d: %v", i, err)
+		}
+	}
+
+	w, err := newRotatingWriter(path, 1)
+	if err != nil {
+		t.Fatalf("newRotatingWriter() returned error: %v", err)
+	}
+
+	if _, err := w.Write([]byte("still going")); err != nil {
+		t.Fatalf("Write() returned error after slots were exhausted: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read %s: %v", path, err)
+	}
+	if string(data) != "still going" {
+		t.Errorf("current log file = %q, want %q", data, "still going")
+	}
+}
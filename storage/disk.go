@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code-harvest.conner.dev/domain"
+	"code-harvest.conner.dev/internal/models"
+)
+
+const millisPerDay = 24 * 60 * 60 * 1000
+
+// diskStorage persists sessions as individual JSON files on disk. It exists
+// mainly as a zero-dependency fallback for local development.
+type diskStorage struct {
+	dir string
+	log Tracer
+}
+
+// DiskStorage returns a TemporaryStorage that writes each session to its own
+// JSON file under the user's cache directory. log may be nil.
+func DiskStorage(log Tracer) *diskStorage {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	return &diskStorage{dir: filepath.Join(cacheDir, "pulse", "sessions"), log: log}
+}
+
+func (d *diskStorage) Connect() func() {
+	os.MkdirAll(d.dir, 0o755)
+	return func() {}
+}
+
+func (d *diskStorage) Save(s interface{}) error {
+	session, ok := s.(*models.Session)
+	if !ok {
+		return fmt.Errorf("storage: expected *models.Session, got %T", s)
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("storage: could not marshal session: %w", err)
+	}
+
+	name := fmt.Sprintf("%d-%d.json", session.StartedAt, time.Now().UTC().UnixNano())
+	path := filepath.Join(d.dir, name)
+	if d.log != nil {
+		d.log.PrintTrace("storage", "Writing session to disk", map[string]string{"path": path})
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// SessionsByDay returns every session whose StartedAt falls within the UTC
+// day that begins at day (a millisecond timestamp already truncated to
+// midnight).
+func (d *diskStorage) SessionsByDay(day int64) ([]domain.Session, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("storage: could not list %s: %w", d.dir, err)
+	}
+
+	end := day + millisPerDay
+	sessions := make([]domain.Session, 0)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(d.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		var session models.Session
+		if err := json.Unmarshal(data, &session); err != nil {
+			continue
+		}
+
+		if session.StartedAt >= day && session.StartedAt < end {
+			sessions = append(sessions, toDomainSession(session))
+		}
+	}
+
+	return sessions, nil
+}
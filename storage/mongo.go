@@ -0,0 +1,291 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"code-harvest.conner.dev/domain"
+	"code-harvest.conner.dev/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Set by linker flags. Falls back to the env vars below when empty.
+var (
+	mongoURI string
+	mongoDB  string
+)
+
+const (
+	envMongoURI           = "PULSE_MONGO_URI"
+	envMongoDB            = "PULSE_MONGO_DB"
+	envMongoBulkSize      = "PULSE_MONGO_BULK_SIZE"
+	envMongoBulkInterval  = "PULSE_MONGO_BULK_INTERVAL_MS"
+	defaultBulkSize       = 50
+	defaultBulkIntervalMs = 30_000
+	maxPingAttempts       = 5
+	pingBackoff           = 2 * time.Second
+	pingInterval          = 30 * time.Second
+)
+
+// MongoStorage is a TemporaryStorage backend that buffers sessions in memory
+// and flushes them to MongoDB in bulk, either once the buffer reaches a
+// configured size or a configured interval elapses. It holds a single
+// long-lived client and lets the driver pool connections per goroutine, so
+// Save is safe to call concurrently.
+type MongoStorage struct {
+	client     *mongo.Client
+	sessions   *mongo.Collection
+	aggregated *mongo.Collection
+
+	mutex         sync.Mutex
+	buffer        []models.Session
+	flushSize     int
+	flushInterval time.Duration
+	flushTimer    *time.Timer
+
+	uri string
+	db  string
+	log Tracer
+}
+
+// NewMongoStorage returns a TemporaryStorage backed by MongoDB. Connection
+// URI and database name are read from linker flags first, falling back to
+// PULSE_MONGO_URI and PULSE_MONGO_DB. The bulk-flush thresholds are read
+// from PULSE_MONGO_BULK_SIZE and PULSE_MONGO_BULK_INTERVAL_MS only. log may
+// be nil.
+func NewMongoStorage(log Tracer) *MongoStorage {
+	uri := mongoURI
+	if uri == "" {
+		uri = os.Getenv(envMongoURI)
+	}
+	db := mongoDB
+	if db == "" {
+		db = os.Getenv(envMongoDB)
+	}
+
+	flushSize := defaultBulkSize
+	if v, err := strconv.Atoi(os.Getenv(envMongoBulkSize)); err == nil && v > 0 {
+		flushSize = v
+	}
+
+	flushInterval := time.Duration(defaultBulkIntervalMs) * time.Millisecond
+	if v, err := strconv.Atoi(os.Getenv(envMongoBulkInterval)); err == nil && v > 0 {
+		flushInterval = time.Duration(v) * time.Millisecond
+	}
+
+	return &MongoStorage{
+		uri:           uri,
+		db:            db,
+		flushSize:     flushSize,
+		flushInterval: flushInterval,
+		buffer:        make([]models.Session, 0, flushSize),
+		log:           log,
+	}
+}
+
+// Connect establishes the client and starts a background goroutine that
+// pings the server on an interval, retrying a handful of times with a
+// backoff before giving up until the next tick. The driver's own connection
+// pool handles the actual reconnecting; this just surfaces a transient DB
+// outage in the logs instead of letting it crash the daemon. It returns a
+// function that flushes any buffered sessions and disconnects.
+func (m *MongoStorage) Connect() func() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(m.uri))
+	if err != nil {
+		panic(fmt.Errorf("storage: could not connect to mongo: %w", err))
+	}
+	m.client = client
+	m.sessions = client.Database(m.db).Collection("sessions")
+	m.aggregated = client.Database(m.db).Collection("aggregated_sessions")
+	if m.log != nil {
+		m.log.PrintTrace("storage", "Connected to mongo", map[string]string{"db": m.db})
+	}
+
+	stopPing := make(chan struct{})
+	go m.keepAlive(stopPing)
+
+	return func() {
+		close(stopPing)
+		m.mutex.Lock()
+		m.flush()
+		m.mutex.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		m.client.Disconnect(ctx)
+	}
+}
+
+// keepAlive pings the server on an interval. If a ping fails it retries a
+// handful of times with a short backoff before giving up until the next
+// tick, rather than taking the whole daemon down.
+func (m *MongoStorage) keepAlive(stop <-chan struct{}) {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			m.pingWithRetry()
+		}
+	}
+}
+
+func (m *MongoStorage) pingWithRetry() {
+	for attempt := 1; attempt <= maxPingAttempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := m.client.Ping(ctx, nil)
+		cancel()
+		if err == nil {
+			return
+		}
+		if m.log != nil {
+			m.log.PrintTrace("storage", "Mongo ping failed, retrying", map[string]string{
+				"attempt": fmt.Sprint(attempt),
+				"reason":  err.Error(),
+			})
+		}
+		time.Sleep(pingBackoff * time.Duration(attempt))
+	}
+}
+
+// Save buffers the session and flushes the buffer in bulk once it reaches
+// the configured size. A timer guarantees that a partially filled buffer is
+// still flushed after flushInterval even if it never fills up.
+func (m *MongoStorage) Save(s interface{}) error {
+	session, ok := s.(*models.Session)
+	if !ok {
+		return fmt.Errorf("storage: expected *models.Session, got %T", s)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.buffer = append(m.buffer, *session)
+	if m.flushTimer == nil {
+		m.flushTimer = time.AfterFunc(m.flushInterval, func() {
+			m.mutex.Lock()
+			defer m.mutex.Unlock()
+			m.flush()
+		})
+	}
+
+	if len(m.buffer) >= m.flushSize {
+		return m.flush()
+	}
+	return nil
+}
+
+// flush upserts every buffered session, keyed by StartedAt and ClientId so
+// that retrying a failed flush never creates duplicates. Callers must hold
+// m.mutex.
+func (m *MongoStorage) flush() error {
+	if len(m.buffer) == 0 {
+		return nil
+	}
+	if m.flushTimer != nil {
+		m.flushTimer.Stop()
+		m.flushTimer = nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(m.buffer))
+	for _, session := range m.buffer {
+		filter := bson.M{"startedAt": session.StartedAt, "clientId": session.ClientId}
+		update := bson.M{"$set": session}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(filter).
+			SetUpdate(update).
+			SetUpsert(true))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := m.sessions.BulkWrite(ctx, writes)
+	if err != nil {
+		return fmt.Errorf("storage: could not bulk write sessions: %w", err)
+	}
+	if m.log != nil {
+		m.log.PrintTrace("storage", "Flushed buffered sessions", map[string]string{"count": fmt.Sprint(len(m.buffer))})
+	}
+
+	m.buffer = m.buffer[:0]
+	return nil
+}
+
+// SaveAggregated upserts the day/week/month/year buckets produced by
+// domain.Sessions.Aggregate (or AggregateBy). It is intentionally typed
+// loosely ([]interface{}), reading back whatever "period" and "date" keys
+// the driver actually produces for the marshaled type, rather than
+// importing domain.AggregatedSession and hard-coding its field names.
+// Upserting by those two fields makes re-running the aggregation job
+// idempotent.
+func (m *MongoStorage) SaveAggregated(aggregated []interface{}) error {
+	if len(aggregated) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(aggregated))
+	for _, session := range aggregated {
+		raw, err := bson.Marshal(session)
+		if err != nil {
+			return fmt.Errorf("storage: could not marshal aggregated session: %w", err)
+		}
+		var doc bson.M
+		if err := bson.Unmarshal(raw, &doc); err != nil {
+			return fmt.Errorf("storage: could not unmarshal aggregated session: %w", err)
+		}
+
+		filter := bson.M{"period": doc["period"], "date": doc["date"]}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(filter).
+			SetUpdate(bson.M{"$set": doc}).
+			SetUpsert(true))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := m.aggregated.BulkWrite(ctx, writes)
+	if err != nil {
+		return fmt.Errorf("storage: could not bulk write aggregated sessions: %w", err)
+	}
+	return nil
+}
+
+// SessionsByDay returns every session whose StartedAt falls within the UTC
+// day that begins at day (a millisecond timestamp already truncated to
+// midnight).
+func (m *MongoStorage) SessionsByDay(day int64) ([]domain.Session, error) {
+	filter := bson.M{"startedAt": bson.M{"$gte": day, "$lt": day + millisPerDay}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cursor, err := m.sessions.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("storage: could not query sessions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	sessions := make([]domain.Session, 0)
+	for cursor.Next(ctx) {
+		var session models.Session
+		if err := cursor.Decode(&session); err != nil {
+			return nil, fmt.Errorf("storage: could not decode session: %w", err)
+		}
+		sessions = append(sessions, toDomainSession(session))
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("storage: could not iterate sessions: %w", err)
+	}
+
+	return sessions, nil
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"code-harvest.conner.dev/domain"
+	"code-harvest.conner.dev/internal/models"
+)
+
+// toDomainSession flattens a stored session into the shape the domain
+// package aggregates over, dropping the in-memory-only bookkeeping fields
+// (ClientId, CurrentFile, OpenFiles) that domain.Session has no use for.
+func toDomainSession(s models.Session) domain.Session {
+	files := make(map[string]domain.File, len(s.Files))
+	for path, file := range s.Files {
+		if file == nil {
+			continue
+		}
+		files[path] = domain.File{
+			Name:       file.Name,
+			Repository: file.Repository,
+			Filetype:   file.Filetype,
+			Path:       file.Path,
+			OpenedAt:   file.OpenedAt,
+			ClosedAt:   file.ClosedAt,
+			DurationMs: file.DurationMs,
+		}
+	}
+
+	return domain.Session{
+		StartedAt:  s.StartedAt,
+		EndedAt:    s.EndedAt,
+		DurationMs: s.DurationMs,
+		OS:         s.OS,
+		Editor:     s.Editor,
+		Files:      files,
+	}
+}
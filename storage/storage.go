@@ -0,0 +1,29 @@
+// Package storage contains the pluggable backends that coding sessions are
+// persisted to once they have ended.
+package storage
+
+// TemporaryStorage is implemented by anything that the app can hand a
+// finished coding session off to. Connect is called once on startup and
+// returns a function that disconnects cleanly on shutdown. Save is called
+// once per finished session.
+type TemporaryStorage interface {
+	Connect() func()
+	Save(s interface{}) error
+}
+
+// Tracer is implemented by loggers that support categorized, env-gated
+// trace output. It is satisfied by *logger.Logger.
+type Tracer interface {
+	PrintTrace(category, message string, properties map[string]string)
+}
+
+// New returns the MongoStorage backend when a connection URI has been
+// configured (via linker flags or PULSE_MONGO_URI), falling back to
+// DiskStorage for local development. log may be nil.
+func New(log Tracer) TemporaryStorage {
+	mongo := NewMongoStorage(log)
+	if mongo.uri == "" {
+		return DiskStorage(log)
+	}
+	return mongo
+}
@@ -0,0 +1,32 @@
+// Package truncate rounds millisecond Unix timestamps down to the start of
+// a calendar bucket (day, ISO week, month or year), always in UTC.
+package truncate
+
+import "time"
+
+// Day truncates ms to midnight UTC of the same day.
+func Day(ms int64) int64 {
+	t := time.UnixMilli(ms).UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).UnixMilli()
+}
+
+// Week truncates ms to midnight UTC of the Monday of that ISO week.
+func Week(ms int64) int64 {
+	day := time.UnixMilli(Day(ms)).UTC()
+	// time.Weekday is Sunday=0 .. Saturday=6. Shift so Monday=0 to find how
+	// many days to subtract to reach the start of the ISO week.
+	daysSinceMonday := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -daysSinceMonday).UnixMilli()
+}
+
+// Month truncates ms to midnight UTC on the first day of that month.
+func Month(ms int64) int64 {
+	t := time.UnixMilli(ms).UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+}
+
+// Year truncates ms to midnight UTC on January 1st of that year.
+func Year(ms int64) int64 {
+	t := time.UnixMilli(ms).UTC()
+	return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+}
@@ -0,0 +1,96 @@
+package truncate
+
+import (
+	"testing"
+	"time"
+)
+
+func ms(year int, month time.Month, day, hour, min int) int64 {
+	return time.Date(year, month, day, hour, min, 0, 0, time.UTC).UnixMilli()
+}
+
+func TestDay(t *testing.T) {
+	tests := []struct {
+		name string
+		in   int64
+		want int64
+	}{
+		{"midday", ms(2024, time.March, 10, 13, 30), ms(2024, time.March, 10, 0, 0)},
+		// US DST "spring forward" has no effect since we always work in UTC.
+		{"us dst spring forward", ms(2024, time.March, 10, 7, 0), ms(2024, time.March, 10, 0, 0)},
+		{"us dst fall back", ms(2024, time.November, 3, 6, 0), ms(2024, time.November, 3, 0, 0)},
+		{"already truncated", ms(2024, time.January, 1, 0, 0), ms(2024, time.January, 1, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Day(tt.in); got != tt.want {
+				t.Errorf("Day(%d) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeek(t *testing.T) {
+	tests := []struct {
+		name string
+		in   int64
+		want int64
+	}{
+		{"mid week", ms(2024, time.March, 14, 9, 0), ms(2024, time.March, 11, 0, 0)},
+		{"sunday rolls back to monday", ms(2024, time.March, 17, 23, 0), ms(2024, time.March, 11, 0, 0)},
+		{"already monday", ms(2024, time.March, 11, 0, 0), ms(2024, time.March, 11, 0, 0)},
+		// Dec 31 2018 is a Monday and starts ISO week 1 of 2019, which runs
+		// into 2019 since the week's Thursday (Jan 3rd) falls in 2019.
+		{"iso week rolls into the new year", ms(2018, time.December, 31, 12, 0), ms(2018, time.December, 31, 0, 0)},
+		// Jan 1 2024 is a Monday, so it starts its own ISO week rather than
+		// belonging to the final week of 2023.
+		{"new year starting on monday", ms(2024, time.January, 1, 5, 0), ms(2024, time.January, 1, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Week(tt.in); got != tt.want {
+				t.Errorf("Week(%d) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonth(t *testing.T) {
+	tests := []struct {
+		name string
+		in   int64
+		want int64
+	}{
+		{"mid month", ms(2024, time.March, 14, 9, 0), ms(2024, time.March, 1, 0, 0)},
+		{"last day of month", ms(2024, time.February, 29, 23, 59), ms(2024, time.February, 1, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Month(tt.in); got != tt.want {
+				t.Errorf("Month(%d) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYear(t *testing.T) {
+	tests := []struct {
+		name string
+		in   int64
+		want int64
+	}{
+		{"mid year", ms(2024, time.July, 4, 12, 0), ms(2024, time.January, 1, 0, 0)},
+		{"new years eve", ms(2024, time.December, 31, 23, 59), ms(2024, time.January, 1, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Year(tt.in); got != tt.want {
+				t.Errorf("Year(%d) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}